@@ -0,0 +1,189 @@
+package securegotcp
+
+import (
+	"crypto/tls"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrSelfConnect is returned by Dial when the dialed socket's remote address
+// equals its local address. This happens when a client misconfigures its
+// target address range and ends up connecting back to itself on the same
+// host.
+var ErrSelfConnect = errors.New("securegotcp: dialed connection points back to itself")
+
+// BackoffConfig controls the exponential backoff with jitter used by
+// Client.DialWithReconnect between redial attempts.
+type BackoffConfig struct {
+	InitialInterval time.Duration // delay before the first retry; defaults to 100ms
+	MaxInterval     time.Duration // upper bound on the retry delay; defaults to 30s
+	Multiplier      float64       // growth factor applied after each attempt; defaults to 2.0
+}
+
+func (b BackoffConfig) withDefaults() BackoffConfig {
+	if b.InitialInterval <= 0 {
+		b.InitialInterval = 100 * time.Millisecond
+	}
+	if b.MaxInterval <= 0 {
+		b.MaxInterval = 30 * time.Second
+	}
+	if b.Multiplier <= 0 {
+		b.Multiplier = 2.0
+	}
+	return b
+}
+
+// next returns a jittered delay derived from interval, then the raw (pre-jitter)
+// interval to use as the base for the following call.
+func (b BackoffConfig) next(interval time.Duration) (delay, nextInterval time.Duration) {
+	if interval > b.MaxInterval {
+		interval = b.MaxInterval
+	}
+	delay = interval/2 + time.Duration(rand.Int63n(int64(interval)/2+1))
+	nextInterval = time.Duration(float64(interval) * b.Multiplier)
+	if nextInterval > b.MaxInterval {
+		nextInterval = b.MaxInterval
+	}
+	return delay, nextInterval
+}
+
+// Client mirrors Server for the dialing side of a connection: it owns the
+// Config, ConnCallback and Protocol used to drive every Conn it creates.
+type Client struct {
+	config    *Config
+	callback  ConnCallback
+	protocol  Protocol
+	exitChan  chan struct{}
+	waitGroup *sync.WaitGroup
+}
+
+// NewClient creates a client
+func NewClient(config *Config, callback ConnCallback, protocol Protocol) *Client {
+	return &Client{
+		config:    config,
+		callback:  callback,
+		protocol:  protocol,
+		exitChan:  make(chan struct{}),
+		waitGroup: &sync.WaitGroup{},
+	}
+}
+
+// The connConfig/connCallback/connProtocol/connExitChan/connWaitGroup methods
+// below satisfy connOwner so Conn can drive a client-dialed connection.
+func (cl *Client) connConfig() *Config            { return cl.config }
+func (cl *Client) connCallback() ConnCallback     { return cl.callback }
+func (cl *Client) connProtocol() Protocol         { return cl.protocol }
+func (cl *Client) connExitChan() chan struct{}    { return cl.exitChan }
+func (cl *Client) connWaitGroup() *sync.WaitGroup { return cl.waitGroup }
+
+// isSelfConnect reports whether local and remote name the same address,
+// which happens when a client accidentally dials back into itself.
+func isSelfConnect(local, remote net.Addr) bool {
+	return local.String() == remote.String()
+}
+
+// Dial connects to addr, optionally negotiates TLS if config.TLSConfig is
+// set, and starts the Conn's read/write/handle loops. It refuses a socket
+// whose remote address equals its local address, which indicates the client
+// accidentally connected back to itself.
+func (cl *Client) Dial(network, addr string, timeout time.Duration) (*Conn, error) {
+	rawConn, err := net.DialTimeout(network, addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSelfConnect(rawConn.LocalAddr(), rawConn.RemoteAddr()) {
+		rawConn.Close()
+		return nil, ErrSelfConnect
+	}
+
+	var conn net.Conn = rawConn
+	if cl.config.TLSConfig != nil {
+		tlsConn := tls.Client(rawConn, cl.config.TLSConfig)
+		if cl.config.TLSHandshakeTimeout > 0 {
+			tlsConn.SetDeadline(time.Now().Add(cl.config.TLSHandshakeTimeout))
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			tlsConn.Close()
+			return nil, err
+		}
+		if cl.config.TLSHandshakeTimeout > 0 {
+			tlsConn.SetDeadline(time.Time{})
+		}
+		conn = tlsConn
+	}
+
+	c := newConn(conn, cl)
+	cl.waitGroup.Add(1)
+	go func() {
+		defer cl.waitGroup.Done()
+		c.Do()
+	}()
+	return c, nil
+}
+
+// DialWithReconnect dials addr and keeps redialing with exponential backoff
+// and jitter whenever the connection is lost, until Stop is called. callback
+// is offered every Conn, including the first; callback.OnReconnect is called
+// on every Conn after the first.
+func (cl *Client) DialWithReconnect(network, addr string, timeout time.Duration, backoff BackoffConfig) error {
+	backoff = backoff.withDefaults()
+
+	c, err := cl.Dial(network, addr, timeout)
+	if err != nil {
+		return err
+	}
+
+	go cl.reconnectLoop(network, addr, timeout, backoff, c)
+	return nil
+}
+
+// reconnectLoop waits on current's own Done channel rather than cl.waitGroup,
+// which is shared with every connection ever dialed on cl (including plain
+// Dial calls made directly by the application); waiting on the WaitGroup
+// would stall reconnection until all of those unrelated connections closed
+// too.
+func (cl *Client) reconnectLoop(network, addr string, timeout time.Duration, backoff BackoffConfig, current *Conn) {
+	interval := backoff.InitialInterval
+	for {
+		select {
+		case <-cl.exitChan:
+			return
+		case <-current.Done():
+		}
+
+		select {
+		case <-cl.exitChan:
+			return
+		default:
+		}
+
+		delay, nextInterval := backoff.next(interval)
+		interval = nextInterval
+
+		select {
+		case <-cl.exitChan:
+			return
+		case <-time.After(delay):
+		}
+
+		c, err := cl.Dial(network, addr, timeout)
+		if err != nil {
+			logErrorf(cl.config.Logger, "reconnect_dial_failed", nil, addr, err)
+			continue
+		}
+
+		interval = backoff.InitialInterval
+		current = c
+		cl.callback.OnReconnect(c)
+	}
+}
+
+// Stop closes the client and stops any in-flight reconnect loop.
+func (cl *Client) Stop() {
+	close(cl.exitChan)
+	cl.waitGroup.Wait()
+}
@@ -0,0 +1,98 @@
+package securegotcp
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingProtocol's ReadPacket only returns once the peer writes or closes;
+// reconnectTest doesn't care about framing, only about readLoop noticing the
+// connection going away.
+type blockingProtocol struct{}
+
+func (blockingProtocol) ReadPacket(conn net.Conn) (Packet, error) {
+	one := make([]byte, 1)
+	if _, err := conn.Read(one); err != nil {
+		return nil, err
+	}
+	return dummyPacket{}, nil
+}
+
+type reconnectTestCallback struct {
+	reconnected chan struct{}
+}
+
+func (reconnectTestCallback) OnConnect(*Conn) bool         { return true }
+func (reconnectTestCallback) OnMessage(*Conn, Packet) bool { return true }
+func (reconnectTestCallback) OnClose(*Conn)                {}
+func (reconnectTestCallback) OnIdle(*Conn) bool            { return true }
+func (c reconnectTestCallback) OnReconnect(*Conn)          { c.reconnected <- struct{}{} }
+
+// TestDialWithReconnectIgnoresUnrelatedDials reproduces the bug where
+// reconnectLoop waited on the Client-wide WaitGroup: an unrelated connection
+// opened via plain Dial must not stall redialing of the connection
+// DialWithReconnect actually manages.
+func TestDialWithReconnectIgnoresUnrelatedDials(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	var mu sync.Mutex
+	var accepted []net.Conn
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepted = append(accepted, conn)
+			mu.Unlock()
+		}
+	}()
+
+	cl := NewClient(&Config{PacketSendChanLimit: 4, PacketReceiveChanLimit: 4}, reconnectTestCallback{reconnected: make(chan struct{}, 1)}, blockingProtocol{})
+	defer cl.Stop()
+
+	// An unrelated, long-lived connection dialed directly; it must stay open
+	// for the whole test and must not be what gates reconnection below.
+	if _, err := cl.Dial("tcp", listener.Addr().String(), time.Second); err != nil {
+		t.Fatalf("Dial (unrelated): %v", err)
+	}
+
+	if err := cl.DialWithReconnect("tcp", listener.Addr().String(), time.Second, BackoffConfig{InitialInterval: 5 * time.Millisecond, MaxInterval: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("DialWithReconnect: %v", err)
+	}
+
+	// Wait for both connections to be accepted server-side, then drop the
+	// second (the one DialWithReconnect manages) to simulate the connection
+	// dying.
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(accepted)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server only accepted %d connections, want 2", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	accepted[1].Close()
+	mu.Unlock()
+
+	cb := cl.callback.(reconnectTestCallback)
+	select {
+	case <-cb.reconnected:
+	case <-time.After(time.Second):
+		t.Fatal("OnReconnect was not called; reconnectLoop appears stalled on the unrelated connection")
+	}
+}
@@ -0,0 +1,30 @@
+package securegotcp
+
+import (
+	"net"
+	"testing"
+)
+
+type fakeAddr string
+
+func (a fakeAddr) Network() string { return "tcp" }
+func (a fakeAddr) String() string  { return string(a) }
+
+func TestIsSelfConnect(t *testing.T) {
+	cases := []struct {
+		name          string
+		local, remote net.Addr
+		want          bool
+	}{
+		{"same address", fakeAddr("127.0.0.1:9000"), fakeAddr("127.0.0.1:9000"), true},
+		{"different port", fakeAddr("127.0.0.1:9000"), fakeAddr("127.0.0.1:9001"), false},
+		{"different host", fakeAddr("127.0.0.1:9000"), fakeAddr("10.0.0.1:9000"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isSelfConnect(c.local, c.remote); got != c.want {
+				t.Fatalf("isSelfConnect(%v, %v) = %v, want %v", c.local, c.remote, got, c.want)
+			}
+		})
+	}
+}
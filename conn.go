@@ -1,16 +1,15 @@
-package gotcp
+package securegotcp
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net"
-	"os"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
-	"path/filepath"
-	"runtime/debug"
-	"svr-frame/libs/tool"
 )
 
 // Error type
@@ -20,16 +19,75 @@ var (
 	ErrReadBlocking  = errors.New("read packet was blocking")
 )
 
+// connOwner is implemented by both Server and Client; it supplies the
+// configuration, callback and protocol that drive a Conn's loops so the same
+// Conn/readLoop/writeLoop/handleLoop infrastructure works on either side of
+// the socket.
+type connOwner interface {
+	connConfig() *Config
+	connCallback() ConnCallback
+	connProtocol() Protocol
+	connExitChan() chan struct{}
+	connWaitGroup() *sync.WaitGroup
+}
+
 // Conn exposes a set of callbacks for the various events that occur on a connection
 type Conn struct {
-	srv               *Server
-	conn              net.Conn  // the raw connection
+	owner             connOwner
+	conn              net.Conn      // the raw connection
 	extraData         interface{}   // to save extra data
 	closeOnce         sync.Once     // close the conn, once, per instance
 	closeFlag         int32         // close flag
 	closeChan         chan struct{} // close chanel
 	packetSendChan    chan Packet   // packet send chanel
 	packetReceiveChan chan Packet   // packeet receive chanel
+
+	// reactorWriteChan is non-nil only when the Conn is driven by a reactor
+	// (Config.Reactor = true); AsyncWritePacket sends there instead of
+	// packetSendChan, and reactorActive guards against two dispatch
+	// goroutines draining the same connection's ring buffer at once.
+	reactorWriteChan chan writeJob
+	reactorActive    int32
+
+	// idleReadConn wraps conn for readLoop's Protocol.ReadPacket calls so
+	// Config.ReadIdleTimeout only ever fires between frames, never mid-frame.
+	idleReadConn *idleReader
+}
+
+// idleReader arms Config.ReadIdleTimeout only on the first Read of a frame
+// (i.e. while waiting for the next frame to start) and clears the deadline
+// entirely once that frame is under way. A multi-read Protocol implementation
+// (e.g. proto.LengthFieldProtocol reading a header then a payload) would
+// otherwise have its deadline fire mid-payload on a connection that is
+// merely slow, not idle; ReadPacket would return a timeout error with the
+// rest of that frame still unread on the socket, and the next ReadPacket
+// call would misparse it as a fresh frame. Restricting the deadline to the
+// gap between frames means a timeout can only ever occur before any bytes
+// of the next frame have been consumed, so resuming with a fresh ReadPacket
+// call after OnIdle is always safe.
+type idleReader struct {
+	net.Conn
+	timeout time.Duration
+	started bool
+}
+
+// beginFrame must be called before every ReadPacket call so its first Read
+// is timed against timeout and every subsequent Read in that call is not.
+func (r *idleReader) beginFrame() {
+	r.started = false
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.timeout <= 0 {
+		return r.Conn.Read(p)
+	}
+	if r.started {
+		r.Conn.SetReadDeadline(time.Time{})
+		return r.Conn.Read(p)
+	}
+	r.started = true
+	r.Conn.SetReadDeadline(time.Now().Add(r.timeout))
+	return r.Conn.Read(p)
 }
 
 // ConnCallback is an interface of methods that are used as callbacks on a connection
@@ -44,16 +102,27 @@ type ConnCallback interface {
 
 	// OnClose is called when the connection closed
 	OnClose(*Conn)
+
+	// OnIdle is called when no packet has been received for
+	// Config.ReadIdleTimeout. If the return value is false the connection
+	// is closed, matching the OnConnect/OnMessage convention.
+	OnIdle(*Conn) bool
+
+	// OnReconnect is called on the Conn that replaces a dropped connection
+	// after Client.DialWithReconnect redials successfully, so applications
+	// can resubscribe or re-authenticate.
+	OnReconnect(*Conn)
 }
 
 // newConn returns a wrapper of raw conn
-func newConn(conn net.Conn, srv *Server) *Conn {
+func newConn(conn net.Conn, owner connOwner) *Conn {
 	return &Conn{
-		srv:               srv,
+		owner:             owner,
 		conn:              conn,
 		closeChan:         make(chan struct{}),
-		packetSendChan:    make(chan Packet, srv.config.PacketSendChanLimit),
-		packetReceiveChan: make(chan Packet, srv.config.PacketReceiveChanLimit),
+		packetSendChan:    make(chan Packet, owner.connConfig().PacketSendChanLimit),
+		packetReceiveChan: make(chan Packet, owner.connConfig().PacketReceiveChanLimit),
+		idleReadConn:      &idleReader{Conn: conn, timeout: owner.connConfig().ReadIdleTimeout},
 	}
 }
 
@@ -72,15 +141,51 @@ func (c *Conn) GetRawConn() net.Conn {
 	return c.conn
 }
 
-// Close closes the connection
+func (c *Conn) remoteAddr() string {
+	if c.conn == nil {
+		return ""
+	}
+	return c.conn.RemoteAddr().String()
+}
+
+// PeerCertificates returns the certificate chain presented by the remote peer
+// during the TLS handshake. It returns nil for plain-text connections.
+func (c *Conn) PeerCertificates() []*x509.Certificate {
+	if tc, ok := c.conn.(*tls.Conn); ok {
+		return tc.ConnectionState().PeerCertificates
+	}
+	return nil
+}
+
+// TLSState returns the TLS connection state negotiated with the remote peer.
+// It returns the zero value for plain-text connections.
+func (c *Conn) TLSState() tls.ConnectionState {
+	if tc, ok := c.conn.(*tls.Conn); ok {
+		return tc.ConnectionState()
+	}
+	return tls.ConnectionState{}
+}
+
+// Close closes the connection. packetSendChan and packetReceiveChan are
+// never closed: AsyncWritePacket and readLoop may be sending on them
+// concurrently, and closing a channel out from under an in-flight send
+// panics. closeChan is the single source of truth instead; every sender
+// selects on it alongside the channel send.
 func (c *Conn) Close() {
 	c.closeOnce.Do(func() {
 		atomic.StoreInt32(&c.closeFlag, 1)
 		close(c.closeChan)
-		close(c.packetSendChan)
-		close(c.packetReceiveChan)
 		c.conn.Close()
-		c.srv.callback.OnClose(c)
+		// ReadPacket is called with c.idleReadConn in the default mode and
+		// with c.conn (wrapped as a *reactorConn) in reactor mode, so a
+		// ProtocolCloser may have cached state under either value
+		// depending on which mode drove this Conn; evicting both is a
+		// no-op for whichever one wasn't actually used as the key.
+		if pc, ok := c.owner.connProtocol().(ProtocolCloser); ok {
+			pc.CloseConn(c.idleReadConn)
+			pc.CloseConn(c.conn)
+		}
+		c.owner.connCallback().OnClose(c)
 	})
 }
 
@@ -89,69 +194,101 @@ func (c *Conn) IsClosed() bool {
 	return atomic.LoadInt32(&c.closeFlag) == 1
 }
 
+// Done returns a channel that is closed once this connection has been
+// Closed, letting a caller wait on one specific Conn instead of an
+// owner-wide WaitGroup that tracks every connection the owner has ever
+// created (see Client.DialWithReconnect).
+func (c *Conn) Done() <-chan struct{} {
+	return c.closeChan
+}
+
 // AsyncWritePacket async writes a packet, this method will never block
-func (c *Conn) AsyncWritePacket(p Packet, timeout time.Duration) (err error) {
+func (c *Conn) AsyncWritePacket(p Packet, timeout time.Duration) error {
 	if c.IsClosed() {
 		return ErrConnClosing
 	}
 
-	defer func() {
-		if e := recover(); e != nil {
-			writeLog(fmt.Sprintf("defer AsyncWritePacket, err:%v, connid:%v", e, c.GetExtraData()))
-			err = ErrConnClosing
-		}
-	}()
+	sendChan := c.packetSendChan
+	var job writeJob
+	if c.reactorWriteChan != nil {
+		job = writeJob{conn: c, pkt: p}
+	}
 
 	if timeout == 0 {
+		if c.reactorWriteChan != nil {
+			select {
+			case c.reactorWriteChan <- job:
+				return nil
+			case <-c.closeChan:
+				return ErrConnClosing
+			default:
+				logWarnf(c.owner.connConfig().Logger, "async_write_blocking", c.GetExtraData(), c.remoteAddr(), ErrWriteBlocking)
+				return ErrWriteBlocking
+			}
+		}
 		select {
-		case c.packetSendChan <- p:
+		case sendChan <- p:
 			return nil
 
+		case <-c.closeChan:
+			return ErrConnClosing
+
 		default:
-			writeLog(fmt.Sprintf("defer AsyncWritePacket, err:%v, connid:%v", ErrWriteBlocking, c.GetExtraData()))
+			logWarnf(c.owner.connConfig().Logger, "async_write_blocking", c.GetExtraData(), c.remoteAddr(), ErrWriteBlocking)
 			return ErrWriteBlocking
 		}
+	}
 
-	} else {
+	if c.reactorWriteChan != nil {
 		select {
-		case c.packetSendChan <- p:
+		case c.reactorWriteChan <- job:
 			return nil
-
 		case <-c.closeChan:
 			return ErrConnClosing
-
 		case <-time.After(timeout):
 			return ErrWriteBlocking
 		}
 	}
+	select {
+	case sendChan <- p:
+		return nil
+
+	case <-c.closeChan:
+		return ErrConnClosing
+
+	case <-time.After(timeout):
+		return ErrWriteBlocking
+	}
 }
 
 // Do it
 func (c *Conn) Do() {
-	if !c.srv.callback.OnConnect(c) {
+	if !c.owner.connCallback().OnConnect(c) {
 		return
 	}
 
-	asyncDo(c.handleLoop, c.srv.waitGroup)
-	asyncDo(c.readLoop, c.srv.waitGroup)
-	asyncDo(c.writeLoop, c.srv.waitGroup)
+	asyncDo(c.handleLoop, c.owner.connWaitGroup())
+	asyncDo(c.readLoop, c.owner.connWaitGroup())
+	asyncDo(c.writeLoop, c.owner.connWaitGroup())
 }
 
 func (c *Conn) readLoop() {
 	addr := c.conn.RemoteAddr().String()
+	logger := c.owner.connConfig().Logger
+	readIdle := c.owner.connConfig().ReadIdleTimeout
 	defer func() {
 		//recover()
 		if r := recover(); r != nil {
-			writeLog(fmt.Sprintf("defer readLoop, panic:%v, connid:%v, addr:%s, stack:%s", r, c.GetExtraData(), addr, string(debug.Stack())))
+			logErrorf(logger, "read_loop_panic", c.GetExtraData(), addr, fmt.Sprintf("%v, stack:%s", r, string(debug.Stack())))
 		} else {
-			writeLog(fmt.Sprintf("defer readLoop, connid:%v, addr:%s", c.GetExtraData(), addr))
+			logInfof(logger, "read_loop_closed", c.GetExtraData(), addr)
 		}
 		c.Close()
 	}()
 
 	for {
 		select {
-		case <-c.srv.exitChan:
+		case <-c.owner.connExitChan():
 			return
 
 		case <-c.closeChan:
@@ -160,31 +297,50 @@ func (c *Conn) readLoop() {
 		default:
 		}
 
-		p, err := c.srv.protocol.ReadPacket(c.conn)
+		c.idleReadConn.beginFrame()
+		p, err := c.owner.connProtocol().ReadPacket(c.idleReadConn)
 		if err != nil {
-			writeLog(fmt.Sprintf("defer readLoop ReadPacket, err:%v, connid:%v, addr:%s", err, c.GetExtraData(), addr))
+			if readIdle > 0 {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					if c.owner.connCallback().OnIdle(c) {
+						continue
+					}
+				}
+			}
+			logErrorf(logger, "read_loop_read_packet_error", c.GetExtraData(), addr, err)
 			return
 		}
 
-		c.packetReceiveChan <- p
+		select {
+		case c.packetReceiveChan <- p:
+		case <-c.closeChan:
+			return
+		}
 	}
 }
 
 func (c *Conn) writeLoop() {
 	addr := c.conn.RemoteAddr().String()
+	logger := c.owner.connConfig().Logger
+	writeIdle := c.owner.connConfig().WriteIdleTimeout
 	defer func() {
 		//recover()
 		if r := recover(); r != nil {
-			writeLog(fmt.Sprintf("defer writeLoop, err:%v, connid:%v, addr:%s, stack:%s", r, c.GetExtraData(), addr, string(debug.Stack())))
+			logErrorf(logger, "write_loop_panic", c.GetExtraData(), addr, fmt.Sprintf("%v, stack:%s", r, string(debug.Stack())))
 		} else {
-			writeLog(fmt.Sprintf("defer writeLoop, connid:%v, addr:%s", c.GetExtraData(), addr))
+			logInfof(logger, "write_loop_closed", c.GetExtraData(), addr)
 		}
 		c.Close()
 	}()
 
 	for {
+		var writeIdleChan <-chan time.Time
+		if writeIdle > 0 {
+			writeIdleChan = time.After(writeIdle)
+		}
+
 		select {
-		case <-c.srv.exitChan:
+		case <-c.owner.connExitChan():
 			return
 
 		case <-c.closeChan:
@@ -195,7 +351,17 @@ func (c *Conn) writeLoop() {
 				return
 			}
 			if _, err := c.conn.Write(p.Serialize()); err != nil {
-				writeLog(fmt.Sprintf("defer writeLoop Write, err:%v, connid:%v, addr:%s", err, c.GetExtraData(), addr))
+				logErrorf(logger, "write_loop_write_error", c.GetExtraData(), addr, err)
+				return
+			}
+
+		case <-writeIdleChan:
+			hb := c.owner.connConfig().HeartbeatProtocol
+			if hb == nil {
+				continue
+			}
+			if _, err := c.conn.Write(hb.Heartbeat().Serialize()); err != nil {
+				logErrorf(logger, "write_loop_heartbeat_error", c.GetExtraData(), addr, err)
 				return
 			}
 		}
@@ -204,19 +370,20 @@ func (c *Conn) writeLoop() {
 
 func (c *Conn) handleLoop() {
 	addr := c.conn.RemoteAddr().String()
+	logger := c.owner.connConfig().Logger
 	defer func() {
 		//recover()
 		if r := recover(); r != nil {
-			writeLog(fmt.Sprintf("defer handleLoop, err:%v, connid:%v, addr:%s, stack:%s", r, c.GetExtraData(), addr, string(debug.Stack())))
+			logErrorf(logger, "handle_loop_panic", c.GetExtraData(), addr, fmt.Sprintf("%v, stack:%s", r, string(debug.Stack())))
 		} else {
-			writeLog(fmt.Sprintf("defer handleLoop, connid:%v, addr:%s", c.GetExtraData(), addr))
+			logInfof(logger, "handle_loop_closed", c.GetExtraData(), addr)
 		}
 		c.Close()
 	}()
 
 	for {
 		select {
-		case <-c.srv.exitChan:
+		case <-c.owner.connExitChan():
 			return
 
 		case <-c.closeChan:
@@ -226,7 +393,7 @@ func (c *Conn) handleLoop() {
 			if c.IsClosed() {
 				return
 			}
-			if !c.srv.callback.OnMessage(c, p) {
+			if !c.owner.connCallback().OnMessage(c, p) {
 				return
 			}
 		}
@@ -240,27 +407,3 @@ func asyncDo(fn func(), wg *sync.WaitGroup) {
 		wg.Done()
 	}()
 }
-
-
-
-var logDir = "./"
-/*
-* 只能设置执行文件所在目录下的一级文件夹
-*/
-func SetLogDir(dir string) {
-	logDir = filepath.Join(filepath.Dir(os.Args[0]), dir)
-
-	if _, err := os.Stat(logDir); os.IsNotExist(err) {
-		os.MkdirAll(logDir, os.ModePerm)
-		fmt.Println("Dir created: ", logDir)
-	}
-}
-
-func init() {
-	logDir = filepath.Dir(os.Args[0])
-}
-
-func writeLog(line string) {
-	path := filepath.Join(logDir, "err.txt")
-	tool.WriteLog(path, line)
-}
@@ -0,0 +1,108 @@
+package securegotcp
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lenPrefixedProtocol is a minimal two-read codec (a 1-byte length prefix,
+// then that many payload bytes) standing in for proto.LengthFieldProtocol:
+// enough to exercise ReadIdleTimeout across more than one Read per frame.
+type lenPrefixedProtocol struct{}
+
+func (lenPrefixedProtocol) ReadPacket(conn net.Conn) (Packet, error) {
+	lenBuf := make([]byte, 1)
+	if _, err := io.ReadFull(conn, lenBuf); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, int(lenBuf[0]))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return nil, err
+	}
+	return lenPrefixedPacket(payload), nil
+}
+
+type lenPrefixedPacket []byte
+
+func (p lenPrefixedPacket) Serialize() []byte { return p }
+
+type idleRecordingCallback struct {
+	received  chan []byte
+	idleCalls chan struct{}
+}
+
+func (c *idleRecordingCallback) OnConnect(*Conn) bool { return true }
+func (c *idleRecordingCallback) OnMessage(_ *Conn, p Packet) bool {
+	c.received <- p.Serialize()
+	return true
+}
+func (c *idleRecordingCallback) OnClose(*Conn) {}
+func (c *idleRecordingCallback) OnIdle(*Conn) bool {
+	c.idleCalls <- struct{}{}
+	return true
+}
+func (c *idleRecordingCallback) OnReconnect(*Conn) {}
+
+// TestReadIdleTimeoutOnlyBetweenFrames reproduces a frame whose payload
+// trickles in slower than ReadIdleTimeout. It must still be delivered whole,
+// without OnIdle firing mid-frame and without desyncing the next frame.
+func TestReadIdleTimeoutOnlyBetweenFrames(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	cb := &idleRecordingCallback{received: make(chan []byte, 2), idleCalls: make(chan struct{}, 10)}
+	owner := &fakeOwner{
+		config: &Config{
+			PacketSendChanLimit:    4,
+			PacketReceiveChanLimit: 4,
+			ReadIdleTimeout:        30 * time.Millisecond,
+		},
+		callback:  cb,
+		protocol:  lenPrefixedProtocol{},
+		exitChan:  make(chan struct{}),
+		waitGroup: &sync.WaitGroup{},
+	}
+	c := newConn(server, owner)
+	defer c.Close()
+
+	go c.readLoop()
+	go c.handleLoop()
+
+	payload := []byte("hello")
+	client.Write([]byte{byte(len(payload))})
+	time.Sleep(60 * time.Millisecond) // longer than ReadIdleTimeout, but mid-frame
+	client.Write(payload)
+
+	select {
+	case got := <-cb.received:
+		if string(got) != string(payload) {
+			t.Fatalf("got %q, want %q", got, payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("frame split across a slow trickle was never delivered")
+	}
+
+	select {
+	case <-cb.idleCalls:
+		t.Fatal("OnIdle fired mid-frame even though bytes were still arriving")
+	default:
+	}
+
+	// The next frame must still parse correctly: the earlier slow trickle
+	// must not have desynced the stream.
+	payload2 := []byte("world")
+	client.Write([]byte{byte(len(payload2))})
+	client.Write(payload2)
+
+	select {
+	case got := <-cb.received:
+		if string(got) != string(payload2) {
+			t.Fatalf("got %q, want %q", got, payload2)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("frame after the slow trickle was not delivered; stream likely desynced")
+	}
+}
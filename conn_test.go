@@ -8,12 +8,169 @@
  * @file: conn_test.go
  * @time: 2018/9/7 10:57
  */
-package gotcp
+package securegotcp
 
-import "testing"
+import (
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
 
-func TestSetLogDir(t *testing.T) {
-	SetLogDir("log4")
+func TestFileLoggerWritesLine(t *testing.T) {
+	path := "log4_test.log"
+	defer os.Remove(path)
 
-	WriteLog("ttttttt")
+	l := NewFileLogger(path)
+	l.Errorf("event=%s err=%v", "test_event", "boom")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(data), "event=test_event err=boom") {
+		t.Fatalf("log file missing expected line, got: %s", data)
+	}
+}
+
+// TestFileLoggerCreatesMissingParentDirs covers the NewFileLogger doc
+// comment's promise to create missing parent directories on first write.
+func TestFileLoggerCreatesMissingParentDirs(t *testing.T) {
+	dir := "log4_test_dir"
+	path := dir + "/nested/log.txt"
+	defer os.RemoveAll(dir)
+
+	l := NewFileLogger(path)
+	l.Errorf("event=%s err=%v", "test_event", "boom")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(data), "event=test_event err=boom") {
+		t.Fatalf("log file missing expected line, got: %s", data)
+	}
+}
+
+type noopCallback struct{}
+
+func (noopCallback) OnConnect(*Conn) bool         { return true }
+func (noopCallback) OnMessage(*Conn, Packet) bool { return true }
+func (noopCallback) OnClose(*Conn)                {}
+func (noopCallback) OnIdle(*Conn) bool            { return true }
+func (noopCallback) OnReconnect(*Conn)            {}
+
+type fakeOwner struct {
+	config    *Config
+	callback  ConnCallback
+	protocol  Protocol
+	exitChan  chan struct{}
+	waitGroup *sync.WaitGroup
+}
+
+func (o *fakeOwner) connConfig() *Config            { return o.config }
+func (o *fakeOwner) connCallback() ConnCallback     { return o.callback }
+func (o *fakeOwner) connProtocol() Protocol         { return o.protocol }
+func (o *fakeOwner) connExitChan() chan struct{}    { return o.exitChan }
+func (o *fakeOwner) connWaitGroup() *sync.WaitGroup { return o.waitGroup }
+
+type dummyPacket struct{}
+
+func (dummyPacket) Serialize() []byte { return nil }
+
+// TestAsyncWritePacketDuringClose spawns many concurrent AsyncWritePacket
+// callers against a Conn that is closed mid-flight. It must never panic and
+// every goroutine must return, proving Close no longer races with sends on
+// packetSendChan.
+func TestAsyncWritePacketDuringClose(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	owner := &fakeOwner{
+		config:    &Config{PacketSendChanLimit: 16, PacketReceiveChanLimit: 16},
+		callback:  noopCallback{},
+		exitChan:  make(chan struct{}),
+		waitGroup: &sync.WaitGroup{},
+	}
+	c := newConn(server, owner)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.AsyncWritePacket(dummyPacket{}, 10*time.Millisecond)
+		}()
+	}
+
+	time.Sleep(time.Millisecond)
+	c.Close()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AsyncWritePacket callers did not return after Close; goroutine leak")
+	}
+}
+
+// closeTrackingProtocol records every net.Conn passed to CloseConn, so tests
+// can assert Conn.Close actually evicts per-connection Protocol state
+// instead of leaking it for the life of the Protocol.
+type closeTrackingProtocol struct {
+	mu     sync.Mutex
+	closed []net.Conn
+}
+
+func (*closeTrackingProtocol) ReadPacket(conn net.Conn) (Packet, error) {
+	<-make(chan struct{}) // block forever; this test never calls ReadPacket
+	return nil, nil
+}
+
+func (p *closeTrackingProtocol) CloseConn(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = append(p.closed, conn)
+}
+
+// TestCloseCallsProtocolCloser verifies Conn.Close notifies a
+// ProtocolCloser Protocol with the net.Conn value(s) ReadPacket was called
+// with, so implementations like proto's bufferedReaders can evict their
+// cached state instead of leaking it for the Protocol's entire lifetime.
+func TestCloseCallsProtocolCloser(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	protocol := &closeTrackingProtocol{}
+	owner := &fakeOwner{
+		config:    &Config{PacketSendChanLimit: 1, PacketReceiveChanLimit: 1},
+		callback:  noopCallback{},
+		protocol:  protocol,
+		exitChan:  make(chan struct{}),
+		waitGroup: &sync.WaitGroup{},
+	}
+	c := newConn(server, owner)
+	c.Close()
+
+	protocol.mu.Lock()
+	defer protocol.mu.Unlock()
+	if len(protocol.closed) == 0 {
+		t.Fatal("CloseConn was never called")
+	}
+	found := false
+	for _, conn := range protocol.closed {
+		if conn == net.Conn(c.idleReadConn) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("CloseConn was not called with the idleReadConn ReadPacket actually reads from: %v", protocol.closed)
+	}
 }
@@ -0,0 +1,88 @@
+package securegotcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Logger is the structured logging interface secure-gotcp writes its
+// internal diagnostics through. Each method takes a printf-style format and
+// args, mirroring the common slog/zap SugaredLogger convention so adapting
+// an existing logger is usually a one-line shim (see SlogLogger, and
+// zaplogger.ZapLogger in its own subpackage so importing zap stays opt-in).
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// noopLogger discards everything; it is the default so Config.Logger is
+// never nil.
+type noopLogger struct{}
+
+func (noopLogger) Debugf(string, ...interface{}) {}
+func (noopLogger) Infof(string, ...interface{})  {}
+func (noopLogger) Warnf(string, ...interface{})  {}
+func (noopLogger) Errorf(string, ...interface{}) {}
+
+func loggerOrDefault(l Logger) Logger {
+	if l == nil {
+		return noopLogger{}
+	}
+	return l
+}
+
+// logInfof/logWarnf/logErrorf format the conn_id/remote_addr/event fields
+// every internal log call site reports, then hand off to the configured
+// Logger (or the no-op default).
+func logInfof(l Logger, event string, connID interface{}, addr string) {
+	loggerOrDefault(l).Infof("event=%s conn_id=%v remote_addr=%s", event, connID, addr)
+}
+
+func logWarnf(l Logger, event string, connID interface{}, addr string, err interface{}) {
+	loggerOrDefault(l).Warnf("event=%s conn_id=%v remote_addr=%s err=%v", event, connID, addr, err)
+}
+
+func logErrorf(l Logger, event string, connID interface{}, addr string, err interface{}) {
+	loggerOrDefault(l).Errorf("event=%s conn_id=%v remote_addr=%s err=%v", event, connID, addr, err)
+}
+
+// FileLogger is a minimal Logger that appends lines to a file. It exists as
+// a dependency-free convenience for callers who don't want to wire in slog
+// or zap, replacing the old package-level SetLogDir/err.txt behavior.
+type FileLogger struct {
+	path string
+}
+
+// NewFileLogger creates a FileLogger that appends to path, creating it (and
+// any missing parent directories) on first write.
+func NewFileLogger(path string) *FileLogger {
+	return &FileLogger{path: path}
+}
+
+func (f *FileLogger) Debugf(format string, args ...interface{}) { f.write("DEBUG", format, args...) }
+func (f *FileLogger) Infof(format string, args ...interface{})  { f.write("INFO", format, args...) }
+func (f *FileLogger) Warnf(format string, args ...interface{})  { f.write("WARN", format, args...) }
+func (f *FileLogger) Errorf(format string, args ...interface{}) { f.write("ERROR", format, args...) }
+
+func (f *FileLogger) write(level, format string, args ...interface{}) {
+	fh, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		if dir := filepath.Dir(f.path); dir != "." {
+			if mkErr := os.MkdirAll(dir, 0755); mkErr != nil {
+				return
+			}
+			fh, err = os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return
+			}
+		} else {
+			return
+		}
+	}
+	defer fh.Close()
+	fmt.Fprintf(fh, "%s [%s] %s\n", time.Now().Format(time.RFC3339), level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,29 @@
+//go:build go1.21
+
+package securegotcp
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger adapts an *slog.Logger to the Logger interface.
+type SlogLogger struct {
+	L *slog.Logger
+}
+
+func (s SlogLogger) Debugf(format string, args ...interface{}) {
+	s.L.Debug(fmt.Sprintf(format, args...))
+}
+
+func (s SlogLogger) Infof(format string, args ...interface{}) {
+	s.L.Info(fmt.Sprintf(format, args...))
+}
+
+func (s SlogLogger) Warnf(format string, args ...interface{}) {
+	s.L.Warn(fmt.Sprintf(format, args...))
+}
+
+func (s SlogLogger) Errorf(format string, args ...interface{}) {
+	s.L.Error(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,44 @@
+package proto
+
+import (
+	"bufio"
+	"net"
+	"sync"
+)
+
+// defaultBufferSize is the per-connection read buffer size used by the
+// codecs below to avoid issuing one syscall per Read (notably
+// DelimiterProtocol, which would otherwise read a single byte at a time).
+const defaultBufferSize = 4096
+
+// bufferedReaders caches one bufio.Reader per net.Conn. Protocol
+// implementations are long-lived singletons shared across every connection
+// they serve, so entries must be released explicitly rather than left to
+// grow forever: the codecs embedding this type implement
+// securegotcp.ProtocolCloser, and Conn.Close calls CloseConn to evict the
+// entry for that connection when it closes.
+type bufferedReaders struct {
+	mu      sync.Mutex
+	readers map[net.Conn]*bufio.Reader
+}
+
+func (b *bufferedReaders) get(conn net.Conn) *bufio.Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.readers == nil {
+		b.readers = make(map[net.Conn]*bufio.Reader)
+	}
+	r, ok := b.readers[conn]
+	if !ok {
+		r = bufio.NewReaderSize(conn, defaultBufferSize)
+		b.readers[conn] = r
+	}
+	return r
+}
+
+// release evicts the cached reader for conn, if any.
+func (b *bufferedReaders) release(conn net.Conn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.readers, conn)
+}
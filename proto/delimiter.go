@@ -0,0 +1,87 @@
+package proto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+
+	securegotcp "github.com/generalzgd/secure-gotcp"
+)
+
+// ErrDelimiterFrameTooLarge is returned by DelimiterProtocol.ReadPacket when
+// no delimiter is found before MaxFrameLength bytes have been read.
+var ErrDelimiterFrameTooLarge = errors.New("proto: delimiter frame exceeds MaxFrameLength")
+
+// DelimiterProtocol decodes frames terminated by an arbitrary byte sequence,
+// e.g. []byte("\n") for line-oriented protocols.
+type DelimiterProtocol struct {
+	Delimiter []byte
+
+	// MaxFrameLength bounds how many bytes may be buffered before Delimiter
+	// is found. Zero (the default zero value of the struct) is treated as
+	// defaultMaxFrameLength rather than "unbounded" - set it to a negative
+	// value if truly unbounded framing is wanted.
+	MaxFrameLength int
+
+	readers bufferedReaders
+}
+
+// CloseConn implements securegotcp.ProtocolCloser, releasing the buffered
+// reader cached for conn so a long-running server doesn't leak one per
+// connection it has ever accepted.
+func (p *DelimiterProtocol) CloseConn(conn net.Conn) {
+	p.readers.release(conn)
+}
+
+func (p *DelimiterProtocol) maxFrameLength() int {
+	if p.MaxFrameLength == 0 {
+		return defaultMaxFrameLength
+	}
+	if p.MaxFrameLength < 0 {
+		return 0
+	}
+	return p.MaxFrameLength
+}
+
+// DelimiterPacket is the Packet produced by DelimiterProtocol. Serialize
+// re-emits the payload followed by the configured delimiter.
+type DelimiterPacket struct {
+	payload   []byte
+	delimiter []byte
+}
+
+func (p *DelimiterPacket) Serialize() []byte {
+	return append(append([]byte{}, p.payload...), p.delimiter...)
+}
+
+// ReadPacket implements securegotcp.Protocol. It reads a byte at a time from
+// a per-connection bufio.Reader, so no bytes belonging to the next frame are
+// ever consumed and discarded, without paying a syscall per byte.
+func (p *DelimiterProtocol) ReadPacket(conn net.Conn) (securegotcp.Packet, error) {
+	if len(p.Delimiter) == 0 {
+		return nil, errors.New("proto: DelimiterProtocol.Delimiter must not be empty")
+	}
+
+	r := p.readers.get(conn)
+	max := p.maxFrameLength()
+
+	var buf bytes.Buffer
+	one := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(r, one); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(one[0])
+
+		if max > 0 && buf.Len() > max {
+			return nil, ErrDelimiterFrameTooLarge
+		}
+
+		if buf.Len() >= len(p.Delimiter) && bytes.Equal(buf.Bytes()[buf.Len()-len(p.Delimiter):], p.Delimiter) {
+			payload := make([]byte, buf.Len()-len(p.Delimiter))
+			copy(payload, buf.Bytes()[:len(payload)])
+			return &DelimiterPacket{payload: payload, delimiter: p.Delimiter}, nil
+		}
+	}
+}
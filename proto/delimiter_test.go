@@ -0,0 +1,52 @@
+package proto
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDelimiterProtocolReadPacket(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	p := &DelimiterProtocol{
+		Delimiter:      []byte("\r\n"),
+		MaxFrameLength: 1024,
+	}
+
+	payload := []byte("hello")
+	go func() {
+		client.Write(payload)
+		client.Write(p.Delimiter)
+	}()
+
+	pkt, err := p.ReadPacket(server)
+	if err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+
+	raw := pkt.Serialize()
+	if string(raw) != "hello\r\n" {
+		t.Fatalf("got %q, want %q", raw, "hello\r\n")
+	}
+}
+
+func TestDelimiterProtocolFrameTooLarge(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	p := &DelimiterProtocol{
+		Delimiter:      []byte("\n"),
+		MaxFrameLength: 4,
+	}
+
+	go func() {
+		client.Write([]byte("hello"))
+	}()
+
+	if _, err := p.ReadPacket(server); err != ErrDelimiterFrameTooLarge {
+		t.Fatalf("got err %v, want ErrDelimiterFrameTooLarge", err)
+	}
+}
@@ -0,0 +1,60 @@
+package proto
+
+import (
+	"errors"
+	"io"
+	"net"
+
+	securegotcp "github.com/generalzgd/secure-gotcp"
+)
+
+// ErrFixedLengthFrameTooLarge is returned by FixedLengthProtocol.ReadPacket
+// when FrameLength exceeds MaxFrameLength.
+var ErrFixedLengthFrameTooLarge = errors.New("proto: fixed frame exceeds MaxFrameLength")
+
+// FixedLengthProtocol decodes frames of a constant, pre-agreed size.
+type FixedLengthProtocol struct {
+	FrameLength int
+
+	// MaxFrameLength is an optional extra guard on top of FrameLength; 0
+	// means FrameLength is the only bound. Unlike LengthFieldProtocol and
+	// DelimiterProtocol, FrameLength itself is always a fixed, caller-chosen
+	// bound, so there is no unbounded-by-default footgun here.
+	MaxFrameLength int
+
+	readers bufferedReaders
+}
+
+// FixedLengthPacket is the Packet produced by FixedLengthProtocol. Serialize
+// re-emits the raw frame bytes.
+type FixedLengthPacket struct {
+	raw []byte
+}
+
+func (p *FixedLengthPacket) Serialize() []byte {
+	return p.raw
+}
+
+// CloseConn implements securegotcp.ProtocolCloser, releasing the buffered
+// reader cached for conn so a long-running server doesn't leak one per
+// connection it has ever accepted.
+func (p *FixedLengthProtocol) CloseConn(conn net.Conn) {
+	p.readers.release(conn)
+}
+
+// ReadPacket implements securegotcp.Protocol.
+func (p *FixedLengthProtocol) ReadPacket(conn net.Conn) (securegotcp.Packet, error) {
+	if p.FrameLength <= 0 {
+		return nil, errors.New("proto: FixedLengthProtocol.FrameLength must be > 0")
+	}
+	if p.MaxFrameLength > 0 && p.FrameLength > p.MaxFrameLength {
+		return nil, ErrFixedLengthFrameTooLarge
+	}
+
+	r := p.readers.get(conn)
+	raw := make([]byte, p.FrameLength)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, err
+	}
+	return &FixedLengthPacket{raw: raw}, nil
+}
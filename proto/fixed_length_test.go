@@ -0,0 +1,42 @@
+package proto
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFixedLengthProtocolReadPacket(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	p := &FixedLengthProtocol{
+		FrameLength: 5,
+	}
+
+	payload := []byte("hello")
+	go func() {
+		client.Write(payload)
+	}()
+
+	pkt, err := p.ReadPacket(server)
+	if err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+
+	raw := pkt.Serialize()
+	if string(raw) != string(payload) {
+		t.Fatalf("got %q, want %q", raw, payload)
+	}
+}
+
+func TestFixedLengthProtocolFrameTooLarge(t *testing.T) {
+	p := &FixedLengthProtocol{
+		FrameLength:    5,
+		MaxFrameLength: 4,
+	}
+
+	if _, err := p.ReadPacket(nil); err != ErrFixedLengthFrameTooLarge {
+		t.Fatalf("got err %v, want ErrFixedLengthFrameTooLarge", err)
+	}
+}
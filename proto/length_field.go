@@ -0,0 +1,121 @@
+package proto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	securegotcp "github.com/generalzgd/secure-gotcp"
+)
+
+// ErrLengthFieldFrameTooLarge is returned by LengthFieldProtocol.ReadPacket
+// when a frame's declared size exceeds MaxFrameLength.
+var ErrLengthFieldFrameTooLarge = errors.New("proto: length-field frame exceeds MaxFrameLength")
+
+// defaultMaxFrameLength is applied by ReadPacket when MaxFrameLength is left
+// at its zero value, so a forgotten MaxFrameLength doesn't silently disable
+// the OOM guard against a malicious or buggy peer.
+const defaultMaxFrameLength = 1 << 20 // 1 MiB
+
+// LengthFieldProtocol decodes frames that carry their own length, mirroring
+// Netty's LengthFieldBasedFrameDecoder: LengthFieldOffset bytes are skipped,
+// then a LengthFieldLength-byte length field is read, then LengthAdjustment
+// is added to get the size of the payload that follows.
+type LengthFieldProtocol struct {
+	ByteOrder         binary.ByteOrder // defaults to binary.BigEndian if nil
+	LengthFieldOffset int              // bytes to skip before the length field
+	LengthFieldLength int              // size of the length field: 1, 2, 4 or 8
+	LengthAdjustment  int              // added to the decoded length to get the payload size
+
+	// MaxFrameLength bounds header+payload size to guard against OOM from a
+	// malicious or buggy peer. Zero (the default zero value of the struct)
+	// is treated as defaultMaxFrameLength rather than "unbounded" - set it
+	// to a negative value if truly unbounded framing is wanted.
+	MaxFrameLength int
+
+	readers bufferedReaders
+}
+
+// CloseConn implements securegotcp.ProtocolCloser, releasing the buffered
+// reader cached for conn so a long-running server doesn't leak one per
+// connection it has ever accepted.
+func (p *LengthFieldProtocol) CloseConn(conn net.Conn) {
+	p.readers.release(conn)
+}
+
+func (p *LengthFieldProtocol) maxFrameLength() int {
+	if p.MaxFrameLength == 0 {
+		return defaultMaxFrameLength
+	}
+	if p.MaxFrameLength < 0 {
+		return 0
+	}
+	return p.MaxFrameLength
+}
+
+// LengthFieldPacket is the Packet produced by LengthFieldProtocol. Serialize
+// re-emits the exact header+payload bytes that were framed on the wire.
+type LengthFieldPacket struct {
+	raw []byte
+}
+
+func (p *LengthFieldPacket) Serialize() []byte {
+	return p.raw
+}
+
+func (p *LengthFieldProtocol) byteOrder() binary.ByteOrder {
+	if p.ByteOrder != nil {
+		return p.ByteOrder
+	}
+	return binary.BigEndian
+}
+
+// ReadPacket implements securegotcp.Protocol.
+func (p *LengthFieldProtocol) ReadPacket(conn net.Conn) (securegotcp.Packet, error) {
+	r := p.readers.get(conn)
+
+	headerLen := p.LengthFieldOffset + p.LengthFieldLength
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	lengthField := header[p.LengthFieldOffset:headerLen]
+	var length int
+	switch p.LengthFieldLength {
+	case 1:
+		length = int(lengthField[0])
+	case 2:
+		length = int(p.byteOrder().Uint16(lengthField))
+	case 4:
+		length = int(p.byteOrder().Uint32(lengthField))
+	case 8:
+		length = int(p.byteOrder().Uint64(lengthField))
+	default:
+		return nil, fmt.Errorf("proto: unsupported LengthFieldLength %d", p.LengthFieldLength)
+	}
+
+	payloadLen := length + p.LengthAdjustment
+	if payloadLen < 0 {
+		return nil, fmt.Errorf("proto: negative payload length %d", payloadLen)
+	}
+	// Bound-check before allocating so a malicious peer can't force an
+	// oversized allocation just by lying about the length field.
+	if max := p.maxFrameLength(); max > 0 && headerLen+payloadLen > max {
+		return nil, ErrLengthFieldFrameTooLarge
+	}
+
+	payload := make([]byte, payloadLen)
+	if payloadLen > 0 {
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	raw := make([]byte, 0, headerLen+payloadLen)
+	raw = append(raw, header...)
+	raw = append(raw, payload...)
+	return &LengthFieldPacket{raw: raw}, nil
+}
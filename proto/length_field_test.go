@@ -0,0 +1,52 @@
+package proto
+
+import (
+	"net"
+	"testing"
+)
+
+func TestLengthFieldProtocolReadPacket(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	p := &LengthFieldProtocol{
+		LengthFieldLength: 2,
+		MaxFrameLength:    1024,
+	}
+
+	payload := []byte("hello")
+	go func() {
+		client.Write([]byte{0x00, byte(len(payload))})
+		client.Write(payload)
+	}()
+
+	pkt, err := p.ReadPacket(server)
+	if err != nil {
+		t.Fatalf("ReadPacket failed: %v", err)
+	}
+
+	raw := pkt.Serialize()
+	if string(raw[2:]) != string(payload) {
+		t.Fatalf("got payload %q, want %q", raw[2:], payload)
+	}
+}
+
+func TestLengthFieldProtocolFrameTooLarge(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	p := &LengthFieldProtocol{
+		LengthFieldLength: 2,
+		MaxFrameLength:    4,
+	}
+
+	go func() {
+		client.Write([]byte{0x00, 0x05})
+	}()
+
+	if _, err := p.ReadPacket(server); err != ErrLengthFieldFrameTooLarge {
+		t.Fatalf("got err %v, want ErrLengthFieldFrameTooLarge", err)
+	}
+}
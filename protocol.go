@@ -11,3 +11,18 @@ type Packet interface {
 type Protocol interface {
 	ReadPacket(conn net.Conn) (Packet, error)
 }
+
+// ProtocolCloser is an optional extension to Protocol for implementations
+// that cache per-connection state keyed by the net.Conn passed to
+// ReadPacket (e.g. a buffered reader). Conn.Close calls CloseConn so that
+// state is released instead of leaking for the lifetime of the Protocol,
+// which is a singleton shared across every connection it ever serves.
+type ProtocolCloser interface {
+	CloseConn(conn net.Conn)
+}
+
+// HeartbeatProtocol produces the Packet written automatically on a Conn
+// whose write side has been idle for Config.WriteIdleTimeout.
+type HeartbeatProtocol interface {
+	Heartbeat() Packet
+}
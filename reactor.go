@@ -0,0 +1,194 @@
+package securegotcp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultReactorWorkers bounds how many connections can be mid-dispatch (a
+// partially buffered frame waiting on more bytes) at once; defaultReactorShards
+// is the number of single-goroutine writers that share the outbound side.
+const (
+	defaultReactorWorkers = 64
+	defaultReactorShards  = 8
+)
+
+// ringBuffer is a small growable byte queue fed by the poller goroutine and
+// drained by whichever worker is currently running Protocol.ReadPacket for
+// that connection.
+type ringBuffer struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	data   []byte
+	closed bool
+}
+
+func newRingBuffer() *ringBuffer {
+	rb := &ringBuffer{}
+	rb.cond = sync.NewCond(&rb.mu)
+	return rb
+}
+
+func (rb *ringBuffer) push(p []byte) {
+	rb.mu.Lock()
+	rb.data = append(rb.data, p...)
+	rb.cond.Signal()
+	rb.mu.Unlock()
+}
+
+func (rb *ringBuffer) empty() bool {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return len(rb.data) == 0
+}
+
+// Read blocks until at least one byte is available or the buffer is closed.
+// A connection with an in-flight partial frame therefore keeps its worker
+// occupied until more bytes arrive; dispatch() avoids pinning a worker to a
+// fully-idle connection by checking empty() between packets instead of
+// calling Read speculatively.
+func (rb *ringBuffer) Read(p []byte) (int, error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for len(rb.data) == 0 && !rb.closed {
+		rb.cond.Wait()
+	}
+	if len(rb.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, rb.data)
+	rb.data = rb.data[n:]
+	return n, nil
+}
+
+func (rb *ringBuffer) close() {
+	rb.mu.Lock()
+	rb.closed = true
+	rb.cond.Broadcast()
+	rb.mu.Unlock()
+}
+
+// reactorConn lets Protocol.ReadPacket, written against net.Conn, run
+// unmodified in reactor mode: Read is served from the connection's
+// ringBuffer instead of the socket directly; everything else forwards to the
+// real net.Conn.
+type reactorConn struct {
+	net.Conn
+	buf *ringBuffer
+}
+
+func (rc *reactorConn) Read(p []byte) (int, error) {
+	return rc.buf.Read(p)
+}
+
+// writeJob is a queued AsyncWritePacket call for a reactor-driven Conn,
+// handled by the shard writer its connection was assigned to.
+type writeJob struct {
+	conn *Conn
+	pkt  Packet
+}
+
+// reactor is implemented per-platform (epoll on Linux, kqueue on BSD/Darwin).
+// It multiplexes reads for many connections across a bounded pool of worker
+// goroutines instead of the default one-goroutine-per-loop-per-connection
+// model, trading a little per-packet latency for O(1) memory per idle
+// connection.
+type reactor interface {
+	register(c *Conn) error
+	run(stop <-chan struct{})
+}
+
+// runShardWriter is shared by every platform backend: one goroutine per
+// shard drains that shard's writeJob channel so writes still happen off a
+// single goroutine per connection group rather than per connection.
+func runShardWriter(shardChan chan writeJob) {
+	for job := range shardChan {
+		if job.conn.IsClosed() {
+			continue
+		}
+		if _, err := job.conn.conn.Write(job.pkt.Serialize()); err != nil {
+			logErrorf(job.conn.owner.connConfig().Logger, "reactor_shard_write_error", job.conn.GetExtraData(), job.conn.remoteAddr(), err)
+			job.conn.Close()
+		}
+	}
+}
+
+// reactorUnsupportedReason reports whether the configured Config is
+// incompatible with reactor mode. The reactor backends register a raw file
+// descriptor directly with epoll/kqueue and have no per-connection
+// readLoop/writeLoop goroutines, so neither TLS (which needs a handshake and
+// a *tls.Conn wrapping the socket) nor the idle-timeout/heartbeat machinery
+// (which lives in readLoop/writeLoop) work under reactor mode today.
+func (s *Server) reactorUnsupportedReason() (error, bool) {
+	switch {
+	case s.config.TLSConfig != nil:
+		return errors.New("securegotcp: Config.Reactor does not support Config.TLSConfig"), true
+	case s.config.ReadIdleTimeout > 0:
+		return errors.New("securegotcp: Config.Reactor does not support Config.ReadIdleTimeout"), true
+	case s.config.WriteIdleTimeout > 0:
+		return errors.New("securegotcp: Config.Reactor does not support Config.WriteIdleTimeout"), true
+	case s.config.HeartbeatProtocol != nil:
+		return errors.New("securegotcp: Config.Reactor does not support Config.HeartbeatProtocol"), true
+	default:
+		return nil, false
+	}
+}
+
+// startReactor runs Start's accept loop in reactor mode: every accepted
+// connection is handed to the platform reactor instead of getting its own
+// readLoop/writeLoop/handleLoop goroutines.
+func (s *Server) startReactor(listener net.Listener, acceptTimeout time.Duration) {
+	if reason, unsupported := s.reactorUnsupportedReason(); unsupported {
+		logWarnf(s.config.Logger, "reactor_config_unsupported", nil, "", reason)
+		s.config.Reactor = false
+		s.Start(listener, acceptTimeout)
+		return
+	}
+
+	rtr, err := newPlatformReactor(s)
+	if err != nil {
+		logWarnf(s.config.Logger, "reactor_unavailable", nil, "", err)
+		s.config.Reactor = false
+		s.Start(listener, acceptTimeout)
+		return
+	}
+
+	s.waitGroup.Add(1)
+	go func() {
+		defer s.waitGroup.Done()
+		rtr.run(s.exitChan)
+	}()
+
+	s.waitGroup.Add(1)
+	defer func() {
+		listener.Close()
+		s.waitGroup.Done()
+	}()
+
+	for {
+		select {
+		case <-s.exitChan:
+			return
+
+		default:
+		}
+
+		if l, ok := listener.(*net.TCPListener); ok {
+			l.SetDeadline(time.Now().Add(acceptTimeout))
+		}
+
+		conn, err := listener.Accept()
+		if err != nil {
+			continue
+		}
+
+		c := newConn(conn, s)
+		if err := rtr.register(c); err != nil {
+			logErrorf(s.config.Logger, "reactor_register_failed", nil, conn.RemoteAddr().String(), err)
+			conn.Close()
+		}
+	}
+}
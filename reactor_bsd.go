@@ -0,0 +1,216 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package securegotcp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+// kqueueReactor is the BSD/Darwin reactor backend. It currently requires
+// plain *net.TCPConn since it needs the raw fd to register with kqueue.
+type kqueueReactor struct {
+	kq       int
+	pool     chan func()
+	shards   []chan writeJob
+	mu       sync.Mutex
+	sessions map[int]*Conn
+	logger   Logger
+}
+
+func newPlatformReactor(srv *Server) (reactor, error) {
+	kq, err := unix.Kqueue()
+	if err != nil {
+		return nil, fmt.Errorf("securegotcp: kqueue: %w", err)
+	}
+
+	r := &kqueueReactor{
+		kq:       kq,
+		pool:     make(chan func(), defaultReactorWorkers),
+		shards:   make([]chan writeJob, defaultReactorShards),
+		sessions: make(map[int]*Conn),
+		logger:   srv.config.Logger,
+	}
+
+	for i := 0; i < defaultReactorWorkers; i++ {
+		go func() {
+			for job := range r.pool {
+				job()
+			}
+		}()
+	}
+	for i := range r.shards {
+		r.shards[i] = make(chan writeJob, 256)
+		go runShardWriter(r.shards[i])
+	}
+
+	return r, nil
+}
+
+func (r *kqueueReactor) register(c *Conn) error {
+	tcpConn, ok := c.conn.(*net.TCPConn)
+	if !ok {
+		return fmt.Errorf("securegotcp: reactor mode requires *net.TCPConn, got %T", c.conn)
+	}
+
+	rawConn, err := tcpConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var fd int
+	var controlErr error
+	if err := rawConn.Control(func(sysfd uintptr) {
+		fd = int(sysfd)
+		controlErr = unix.SetNonblock(fd, true)
+	}); err != nil {
+		return err
+	}
+	if controlErr != nil {
+		return controlErr
+	}
+
+	c.conn = &reactorConn{Conn: tcpConn, buf: newRingBuffer()}
+	c.reactorWriteChan = r.shards[fd%len(r.shards)]
+
+	r.mu.Lock()
+	r.sessions[fd] = c
+	r.mu.Unlock()
+
+	event := unix.Kevent_t{
+		Ident:  uint64(fd),
+		Filter: unix.EVFILT_READ,
+		Flags:  unix.EV_ADD | unix.EV_ENABLE,
+	}
+	if _, err := unix.Kevent(r.kq, []unix.Kevent_t{event}, nil, nil); err != nil {
+		r.mu.Lock()
+		delete(r.sessions, fd)
+		r.mu.Unlock()
+		return err
+	}
+
+	if !c.owner.connCallback().OnConnect(c) {
+		r.closeSession(fd, c)
+	}
+	return nil
+}
+
+func (r *kqueueReactor) closeSession(fd int, c *Conn) {
+	r.mu.Lock()
+	delete(r.sessions, fd)
+	r.mu.Unlock()
+
+	event := unix.Kevent_t{
+		Ident:  uint64(fd),
+		Filter: unix.EVFILT_READ,
+		Flags:  unix.EV_DELETE,
+	}
+	unix.Kevent(r.kq, []unix.Kevent_t{event}, nil, nil)
+	if rc, ok := c.conn.(*reactorConn); ok {
+		rc.buf.close()
+	}
+	c.Close()
+}
+
+func (r *kqueueReactor) run(stop <-chan struct{}) {
+	events := make([]unix.Kevent_t, 128)
+	readBuf := make([]byte, 64*1024)
+	for {
+		select {
+		case <-stop:
+			unix.Close(r.kq)
+			return
+		default:
+		}
+
+		// A 1s timeout, matching the epoll backend's EpollWait(..., 1000),
+		// so stop is rechecked periodically instead of only between fd
+		// events - without it, Server.Stop could hang indefinitely on an
+		// otherwise-idle reactor.
+		n, err := unix.Kevent(r.kq, nil, events, &unix.Timespec{Sec: 1, Nsec: 0})
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			logErrorf(r.logger, "reactor_kevent_error", nil, "", err)
+			return
+		}
+
+		for i := 0; i < n; i++ {
+			fd := int(events[i].Ident)
+			r.mu.Lock()
+			c := r.sessions[fd]
+			r.mu.Unlock()
+			if c == nil {
+				continue
+			}
+
+			nr, err := unix.Read(fd, readBuf)
+			if err != nil || nr == 0 {
+				r.closeSession(fd, c)
+				continue
+			}
+
+			rc := c.conn.(*reactorConn)
+			rc.buf.push(readBuf[:nr])
+			r.scheduleDispatch(c)
+		}
+	}
+}
+
+// scheduleDispatch submits a parse job for c unless one is already draining
+// its ring buffer; that existing job will observe the bytes just pushed.
+func (r *kqueueReactor) scheduleDispatch(c *Conn) {
+	if !atomic.CompareAndSwapInt32(&c.reactorActive, 0, 1) {
+		return
+	}
+	select {
+	case r.pool <- func() { r.dispatch(c) }:
+	default:
+		atomic.StoreInt32(&c.reactorActive, 0)
+	}
+}
+
+// dispatch drains c's ring buffer. Before returning because the buffer looks
+// empty, it resets reactorActive and re-checks: without that re-check, bytes
+// pushed (and a scheduleDispatch fired) in the window between the empty
+// check and the deferred reset would find the CAS still held by this very
+// goroutine, lose the race, and never get redispatched - the reset would
+// then land with the flag clear but those bytes still unprocessed until
+// unrelated future activity on the fd woke it back up.
+func (r *kqueueReactor) dispatch(c *Conn) {
+	rc := c.conn.(*reactorConn)
+	for {
+		if c.IsClosed() {
+			atomic.StoreInt32(&c.reactorActive, 0)
+			return
+		}
+		if rc.buf.empty() {
+			atomic.StoreInt32(&c.reactorActive, 0)
+			if rc.buf.empty() {
+				return
+			}
+			if !atomic.CompareAndSwapInt32(&c.reactorActive, 0, 1) {
+				return
+			}
+			continue
+		}
+
+		p, err := c.owner.connProtocol().ReadPacket(c.conn)
+		if err != nil {
+			logErrorf(c.owner.connConfig().Logger, "reactor_dispatch_read_packet_error", c.GetExtraData(), c.remoteAddr(), err)
+			atomic.StoreInt32(&c.reactorActive, 0)
+			c.Close()
+			return
+		}
+		if !c.owner.connCallback().OnMessage(c, p) {
+			atomic.StoreInt32(&c.reactorActive, 0)
+			c.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,203 @@
+//go:build linux
+
+package securegotcp
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+// epollReactor is the Linux reactor backend. It currently requires plain
+// *net.TCPConn since it needs the raw fd to register with epoll.
+type epollReactor struct {
+	epfd     int
+	pool     chan func()
+	shards   []chan writeJob
+	mu       sync.Mutex
+	sessions map[int]*Conn
+	logger   Logger
+}
+
+func newPlatformReactor(srv *Server) (reactor, error) {
+	epfd, err := unix.EpollCreate1(0)
+	if err != nil {
+		return nil, fmt.Errorf("securegotcp: epoll_create1: %w", err)
+	}
+
+	r := &epollReactor{
+		epfd:     epfd,
+		pool:     make(chan func(), defaultReactorWorkers),
+		shards:   make([]chan writeJob, defaultReactorShards),
+		sessions: make(map[int]*Conn),
+		logger:   srv.config.Logger,
+	}
+
+	for i := 0; i < defaultReactorWorkers; i++ {
+		go func() {
+			for job := range r.pool {
+				job()
+			}
+		}()
+	}
+	for i := range r.shards {
+		r.shards[i] = make(chan writeJob, 256)
+		go runShardWriter(r.shards[i])
+	}
+
+	return r, nil
+}
+
+func (r *epollReactor) register(c *Conn) error {
+	tcpConn, ok := c.conn.(*net.TCPConn)
+	if !ok {
+		return fmt.Errorf("securegotcp: reactor mode requires *net.TCPConn, got %T", c.conn)
+	}
+
+	rawConn, err := tcpConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var fd int
+	var controlErr error
+	if err := rawConn.Control(func(sysfd uintptr) {
+		fd = int(sysfd)
+		controlErr = unix.SetNonblock(fd, true)
+	}); err != nil {
+		return err
+	}
+	if controlErr != nil {
+		return controlErr
+	}
+
+	c.conn = &reactorConn{Conn: tcpConn, buf: newRingBuffer()}
+	c.reactorWriteChan = r.shards[fd%len(r.shards)]
+
+	r.mu.Lock()
+	r.sessions[fd] = c
+	r.mu.Unlock()
+
+	event := unix.EpollEvent{Events: unix.EPOLLIN, Fd: int32(fd)}
+	if err := unix.EpollCtl(r.epfd, unix.EPOLL_CTL_ADD, fd, &event); err != nil {
+		r.mu.Lock()
+		delete(r.sessions, fd)
+		r.mu.Unlock()
+		return err
+	}
+
+	if !c.owner.connCallback().OnConnect(c) {
+		r.closeSession(fd, c)
+	}
+	return nil
+}
+
+func (r *epollReactor) closeSession(fd int, c *Conn) {
+	r.mu.Lock()
+	delete(r.sessions, fd)
+	r.mu.Unlock()
+
+	unix.EpollCtl(r.epfd, unix.EPOLL_CTL_DEL, fd, nil)
+	if rc, ok := c.conn.(*reactorConn); ok {
+		rc.buf.close()
+	}
+	c.Close()
+}
+
+func (r *epollReactor) run(stop <-chan struct{}) {
+	events := make([]unix.EpollEvent, 128)
+	readBuf := make([]byte, 64*1024)
+	for {
+		select {
+		case <-stop:
+			unix.Close(r.epfd)
+			return
+		default:
+		}
+
+		n, err := unix.EpollWait(r.epfd, events, 1000)
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			logErrorf(r.logger, "reactor_epoll_wait_error", nil, "", err)
+			return
+		}
+
+		for i := 0; i < n; i++ {
+			fd := int(events[i].Fd)
+			r.mu.Lock()
+			c := r.sessions[fd]
+			r.mu.Unlock()
+			if c == nil {
+				continue
+			}
+
+			nr, err := unix.Read(fd, readBuf)
+			if err != nil || nr == 0 {
+				r.closeSession(fd, c)
+				continue
+			}
+
+			rc := c.conn.(*reactorConn)
+			rc.buf.push(readBuf[:nr])
+			r.scheduleDispatch(c)
+		}
+	}
+}
+
+// scheduleDispatch submits a parse job for c unless one is already draining
+// its ring buffer; that existing job will observe the bytes just pushed.
+func (r *epollReactor) scheduleDispatch(c *Conn) {
+	if !atomic.CompareAndSwapInt32(&c.reactorActive, 0, 1) {
+		return
+	}
+	select {
+	case r.pool <- func() { r.dispatch(c) }:
+	default:
+		atomic.StoreInt32(&c.reactorActive, 0)
+	}
+}
+
+// dispatch drains c's ring buffer. Before returning because the buffer looks
+// empty, it resets reactorActive and re-checks: without that re-check, bytes
+// pushed (and a scheduleDispatch fired) in the window between the empty
+// check and the deferred reset would find the CAS still held by this very
+// goroutine, lose the race, and never get redispatched - the reset would
+// then land with the flag clear but those bytes still unprocessed until
+// unrelated future activity on the fd woke it back up.
+func (r *epollReactor) dispatch(c *Conn) {
+	rc := c.conn.(*reactorConn)
+	for {
+		if c.IsClosed() {
+			atomic.StoreInt32(&c.reactorActive, 0)
+			return
+		}
+		if rc.buf.empty() {
+			atomic.StoreInt32(&c.reactorActive, 0)
+			if rc.buf.empty() {
+				return
+			}
+			if !atomic.CompareAndSwapInt32(&c.reactorActive, 0, 1) {
+				return
+			}
+			continue
+		}
+
+		p, err := c.owner.connProtocol().ReadPacket(c.conn)
+		if err != nil {
+			logErrorf(c.owner.connConfig().Logger, "reactor_dispatch_read_packet_error", c.GetExtraData(), c.remoteAddr(), err)
+			atomic.StoreInt32(&c.reactorActive, 0)
+			c.Close()
+			return
+		}
+		if !c.owner.connCallback().OnMessage(c, p) {
+			atomic.StoreInt32(&c.reactorActive, 0)
+			c.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+//go:build linux
+
+package securegotcp
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProtocol treats every single byte as one packet, so pushing N
+// bytes into a ringBuffer should eventually result in exactly N OnMessage
+// calls once fully dispatched.
+type countingProtocol struct{}
+
+func (countingProtocol) ReadPacket(conn net.Conn) (Packet, error) {
+	b := make([]byte, 1)
+	if _, err := conn.Read(b); err != nil {
+		return nil, err
+	}
+	return dummyPacket{}, nil
+}
+
+type countingCallback struct {
+	count int64
+}
+
+func (c *countingCallback) OnConnect(*Conn) bool { return true }
+func (c *countingCallback) OnMessage(*Conn, Packet) bool {
+	atomic.AddInt64(&c.count, 1)
+	return true
+}
+func (c *countingCallback) OnClose(*Conn)     {}
+func (c *countingCallback) OnIdle(*Conn) bool { return true }
+func (c *countingCallback) OnReconnect(*Conn) {}
+
+// TestEpollReactorDispatchNoLostWakeup stress-tests scheduleDispatch/dispatch
+// with many concurrent push+schedule calls racing against dispatch workers
+// observing the ring buffer as empty. Before the fix, a push landing in the
+// window between dispatch's empty check and its deferred reactorActive
+// reset could be dropped: the CAS in scheduleDispatch would fail (the flag
+// still held by the returning dispatch call) and the reset would then clear
+// it with nobody scheduled to notice the new bytes, leaving them stuck in
+// the buffer until unrelated future activity. This test would time out
+// under that bug.
+func TestEpollReactorDispatchNoLostWakeup(t *testing.T) {
+	const goroutines = 8
+	const perGoroutine = 500
+	const total = goroutines * perGoroutine
+
+	cb := &countingCallback{}
+	owner := &fakeOwner{
+		config:    &Config{},
+		callback:  cb,
+		protocol:  countingProtocol{},
+		exitChan:  make(chan struct{}),
+		waitGroup: &sync.WaitGroup{},
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	c := newConn(server, owner)
+	buf := newRingBuffer()
+	c.conn = &reactorConn{Conn: server, buf: buf}
+
+	r := &epollReactor{pool: make(chan func(), defaultReactorWorkers), logger: noopLogger{}}
+	for i := 0; i < defaultReactorWorkers; i++ {
+		go func() {
+			for job := range r.pool {
+				job()
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				buf.push([]byte{1})
+				r.scheduleDispatch(c)
+			}
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if atomic.LoadInt64(&cb.count) == int64(total) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d dispatched packets, want %d; bytes appear stuck in the ring buffer", atomic.LoadInt64(&cb.count), total)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
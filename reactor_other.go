@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package securegotcp
+
+import "fmt"
+
+// newPlatformReactor reports that reactor mode has no backend on this
+// platform; startReactor falls back to the default goroutine-per-connection
+// path when this returns an error.
+func newPlatformReactor(srv *Server) (reactor, error) {
+	return nil, fmt.Errorf("securegotcp: reactor mode is not supported on this platform")
+}
@@ -1,6 +1,7 @@
 package securegotcp
 
 import (
+	"crypto/tls"
 	"net"
 	"sync"
 	"time"
@@ -9,6 +10,46 @@ import (
 type Config struct {
 	PacketSendChanLimit    uint32 // the limit of packet send channel
 	PacketReceiveChanLimit uint32 // the limit of packet receive channel
+
+	// TLSConfig, when non-nil, makes Start negotiate TLS on every accepted
+	// connection before ConnCallback.OnConnect is invoked. Set ClientAuth to
+	// tls.RequireAndVerifyClientCert for mutual TLS; OnConnect can then
+	// authorize the peer via Conn.PeerCertificates().
+	TLSConfig *tls.Config
+
+	// TLSHandshakeTimeout bounds how long the TLS handshake may take. Zero
+	// means no deadline is applied.
+	TLSHandshakeTimeout time.Duration
+
+	// ReadIdleTimeout, when non-zero, invokes ConnCallback.OnIdle if no
+	// packet is received within the timeout. Reading resumes if OnIdle
+	// returns true; the connection is closed if it returns false.
+	ReadIdleTimeout time.Duration
+
+	// WriteIdleTimeout, when non-zero, makes the write side send
+	// HeartbeatProtocol's packet automatically after no packet has been
+	// written for the timeout.
+	WriteIdleTimeout time.Duration
+
+	// HeartbeatProtocol produces the packet sent on write idle. It is
+	// required for WriteIdleTimeout to have any effect.
+	HeartbeatProtocol HeartbeatProtocol
+
+	// Reactor switches Start to an epoll (Linux) / kqueue (BSD, Darwin)
+	// driven mode that multiplexes reads across a bounded worker pool
+	// instead of spawning readLoop/writeLoop/handleLoop per connection. It
+	// falls back to the default mode with a logged warning if the platform
+	// doesn't support it, or if TLSConfig, ReadIdleTimeout, WriteIdleTimeout
+	// or HeartbeatProtocol is also set - none of those are implemented in
+	// reactor mode. It requires plain *net.TCPConn: an accepted connection
+	// of any other type is not served in the default mode but logged as an
+	// error and closed.
+	Reactor bool
+
+	// Logger receives every internal diagnostic this package used to write
+	// to err.txt. Defaults to a no-op logger; see FileLogger, SlogLogger and
+	// the zaplogger subpackage for ready-made adapters.
+	Logger Logger
 }
 
 type Server struct {
@@ -30,8 +71,29 @@ func NewServer(config *Config, callback ConnCallback, protocol Protocol) *Server
 	}
 }
 
+// NewTLSServer creates a server that negotiates TLS (optionally mTLS, via
+// tlsConfig.ClientAuth) on every accepted connection before handing it off
+// to callback.OnConnect.
+func NewTLSServer(config *Config, callback ConnCallback, protocol Protocol, tlsConfig *tls.Config) *Server {
+	config.TLSConfig = tlsConfig
+	return NewServer(config, callback, protocol)
+}
+
+// The connConfig/connCallback/connProtocol/connExitChan/connWaitGroup methods
+// below satisfy connOwner so Conn can drive a server-accepted connection.
+func (s *Server) connConfig() *Config            { return s.config }
+func (s *Server) connCallback() ConnCallback     { return s.callback }
+func (s *Server) connProtocol() Protocol         { return s.protocol }
+func (s *Server) connExitChan() chan struct{}    { return s.exitChan }
+func (s *Server) connWaitGroup() *sync.WaitGroup { return s.waitGroup }
+
 // Start starts service
 func (s *Server) Start(listener net.Listener, acceptTimeout time.Duration) {
+	if s.config.Reactor {
+		s.startReactor(listener, acceptTimeout)
+		return
+	}
+
 	s.waitGroup.Add(1)
 	defer func() {
 		listener.Close()
@@ -57,8 +119,26 @@ func (s *Server) Start(listener net.Listener, acceptTimeout time.Duration) {
 
 		s.waitGroup.Add(1)
 		go func() {
-			newConn(conn, s).Do()
-			s.waitGroup.Done()
+			defer s.waitGroup.Done()
+
+			rawConn := conn
+			if s.config.TLSConfig != nil {
+				tlsConn := tls.Server(rawConn, s.config.TLSConfig)
+				if s.config.TLSHandshakeTimeout > 0 {
+					tlsConn.SetDeadline(time.Now().Add(s.config.TLSHandshakeTimeout))
+				}
+				if err := tlsConn.Handshake(); err != nil {
+					logErrorf(s.config.Logger, "tls_handshake_failed", nil, rawConn.RemoteAddr().String(), err)
+					tlsConn.Close()
+					return
+				}
+				if s.config.TLSHandshakeTimeout > 0 {
+					tlsConn.SetDeadline(time.Time{})
+				}
+				rawConn = tlsConn
+			}
+
+			newConn(rawConn, s).Do()
 		}()
 	}
 }
@@ -0,0 +1,187 @@
+package securegotcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// genCert issues a leaf certificate signed by ca (or self-signed if ca is
+// nil), returning both the tls.Certificate form and the parsed x509 form.
+func genCert(t *testing.T, commonName string, isCA bool, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (tls.Certificate, *x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+
+	parent, parentKey := template, key
+	if ca != nil {
+		parent, parentKey = ca, caKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert, key
+}
+
+type tlsRecordingCallback struct {
+	connected chan *Conn
+}
+
+func (c *tlsRecordingCallback) OnConnect(conn *Conn) bool {
+	c.connected <- conn
+	return true
+}
+func (c *tlsRecordingCallback) OnMessage(*Conn, Packet) bool { return true }
+func (c *tlsRecordingCallback) OnClose(*Conn)                {}
+func (c *tlsRecordingCallback) OnIdle(*Conn) bool            { return true }
+func (c *tlsRecordingCallback) OnReconnect(*Conn)            {}
+
+type oneByteProtocol struct{}
+
+func (oneByteProtocol) ReadPacket(conn net.Conn) (Packet, error) {
+	b := make([]byte, 1)
+	if _, err := conn.Read(b); err != nil {
+		return nil, err
+	}
+	return dummyPacket{}, nil
+}
+
+// TestTLSServerMutualAuth exercises Config.TLSConfig end to end with mutual
+// TLS: the server requires and verifies a client certificate, and OnConnect
+// must be able to read it back via Conn.PeerCertificates().
+func TestTLSServerMutualAuth(t *testing.T) {
+	_, caCert, caKey := genCert(t, "test-ca", true, nil, nil)
+	serverCert, _, _ := genCert(t, "127.0.0.1", false, caCert, caKey)
+	clientCert, clientLeaf, _ := genCert(t, "test-client", false, caCert, caKey)
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	cb := &tlsRecordingCallback{connected: make(chan *Conn, 1)}
+	srv := NewTLSServer(
+		&Config{PacketSendChanLimit: 4, PacketReceiveChanLimit: 4},
+		cb,
+		oneByteProtocol{},
+		&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    caPool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		},
+	)
+	go srv.Start(listener, 100*time.Millisecond)
+	defer srv.Stop()
+
+	clientConn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      caPool,
+		ServerName:   "127.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("tls.Dial: %v", err)
+	}
+	defer clientConn.Close()
+
+	select {
+	case conn := <-cb.connected:
+		peers := conn.PeerCertificates()
+		if len(peers) != 1 || peers[0].Subject.CommonName != clientLeaf.Subject.CommonName {
+			t.Fatalf("PeerCertificates = %v, want client cert with CN %q", peers, clientLeaf.Subject.CommonName)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnConnect was not called; TLS handshake likely failed")
+	}
+}
+
+// TestTLSServerRejectsUntrustedClient ensures a client without a
+// CA-signed certificate is refused when mutual TLS is required.
+func TestTLSServerRejectsUntrustedClient(t *testing.T) {
+	_, caCert, caKey := genCert(t, "test-ca", true, nil, nil)
+	serverCert, _, _ := genCert(t, "127.0.0.1", false, caCert, caKey)
+	untrustedCert, _, _ := genCert(t, "untrusted-client", false, nil, nil)
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	cb := &tlsRecordingCallback{connected: make(chan *Conn, 1)}
+	srv := NewTLSServer(
+		&Config{PacketSendChanLimit: 4, PacketReceiveChanLimit: 4},
+		cb,
+		oneByteProtocol{},
+		&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    caPool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		},
+	)
+	go srv.Start(listener, 100*time.Millisecond)
+	defer srv.Stop()
+
+	clientConn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		Certificates: []tls.Certificate{untrustedCert},
+		RootCAs:      caPool,
+		ServerName:   "127.0.0.1",
+	})
+	if err != nil {
+		// A pre-TLS-1.3 peer can reject the handshake outright.
+		return
+	}
+	defer clientConn.Close()
+
+	// TLS 1.3 clients finish their own handshake before learning whether the
+	// server accepted their certificate (the server verifies it from a
+	// record sent after the client's Finished), so the failure only shows up
+	// once real application data is exchanged.
+	clientConn.SetDeadline(time.Now().Add(time.Second))
+	clientConn.Write([]byte{1})
+	if _, err := clientConn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("read from server succeeded despite an untrusted client certificate")
+	}
+
+	select {
+	case conn := <-cb.connected:
+		t.Fatalf("OnConnect was called for an untrusted client: %v", conn)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,17 @@
+// Package zaplogger adapts a *zap.SugaredLogger to securegotcp.Logger. It is
+// a separate package so that importing the root securegotcp package never
+// pulls in zap (and its transitive multierr dependency) for callers who
+// don't use this adapter.
+package zaplogger
+
+import "go.uber.org/zap"
+
+// ZapLogger adapts a *zap.SugaredLogger to securegotcp.Logger.
+type ZapLogger struct {
+	L *zap.SugaredLogger
+}
+
+func (z ZapLogger) Debugf(format string, args ...interface{}) { z.L.Debugf(format, args...) }
+func (z ZapLogger) Infof(format string, args ...interface{})  { z.L.Infof(format, args...) }
+func (z ZapLogger) Warnf(format string, args ...interface{})  { z.L.Warnf(format, args...) }
+func (z ZapLogger) Errorf(format string, args ...interface{}) { z.L.Errorf(format, args...) }